@@ -2,17 +2,34 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
-	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptrace"
 	"os"
+	"os/signal"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"text/template"
+	"golang.org/x/time/rate"
+)
+
+// ErrClass categorizes why a request did not produce a clean 2xx
+// response, so sumarize can build an error histogram instead of aborting
+// the whole benchmark on the first failure.
+type ErrClass string
+
+const (
+	ErrNone    ErrClass = ""
+	ErrDNS     ErrClass = "dns"
+	ErrConnect ErrClass = "connect"
+	ErrTLS     ErrClass = "tls"
+	ErrTimeout ErrClass = "timeout"
+	ErrRead    ErrClass = "read"
+	ErrNon2xx  ErrClass = "non-2xx"
 )
 
 type Stat struct {
@@ -22,48 +39,121 @@ type Stat struct {
 	ServerProccesing time.Duration
 	ContentTransfer  time.Duration
 	Total            time.Duration
+	StatusCode       int
+	Err              ErrClass
 }
 
 var c int
 var t int
+var d time.Duration
+var timeout time.Duration
 
 func init() {
 	flag.IntVar(&c, "c", 1, "Number of connections. It should be > 0.")
 	flag.IntVar(&t, "t", 1, "Total calls.")
+	flag.DurationVar(&d, "d", 0, "Run for this duration instead of a fixed number of calls, e.g. 30s. Mutually exclusive with -t.")
+	flag.DurationVar(&timeout, "timeout", 0, "Per-request timeout, e.g. 5s. Zero means no timeout.")
 }
 
 func main() {
 	flag.Parse()
 	fsArgs := flag.Args()
-	if len(fsArgs) == 0 {
+	if len(fsArgs) == 0 && urlsFile == "" {
 		os.Exit(1)
 	}
+	if d > 0 && t > 1 {
+		log.Fatalf("-t and -d are mutually exclusive")
+	}
+	if err := resolveBodyFlag(); err != nil {
+		log.Fatalf("%v", err)
+	}
 	tc := t
 	nc := c
-	stats := make(chan Stat, tc*2)
-	url := fsArgs[0]
-	bench(url, tc, nc, stats)
-	sumarize(stats, os.Stdout)
+	stats := make(chan Stat, nc*64)
+
+	var positionalURL string
+	if len(fsArgs) > 0 {
+		positionalURL = fsArgs[0]
+	}
+	targets, err := loadTargets(positionalURL)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client, err := buildClient()
+	if err != nil {
+		log.Fatalf("building HTTP client: %v", err)
+	}
+	limiter := buildLimiter()
+
+	samples := make([]Stat, 0, tc)
+	collected := make(chan struct{})
+	go func() {
+		for s := range stats {
+			samples = append(samples, s)
+		}
+		close(collected)
+	}()
+
+	start := time.Now()
+	bench(ctx, client, limiter, targets, tc, nc, d, stats)
+	close(stats)
+	<-collected
+	elapsed := time.Since(start)
+
+	reporter, err := newReporter(outputFormat)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	out, err := openOutput(outputFile)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer out.Close()
+	if err := reporter.Report(samples, elapsed, out); err != nil {
+		log.Fatalf("writing report: %v", err)
+	}
 	os.Exit(0)
 }
 
-func bench(url string, quantity int, threads int, stats chan Stat) {
+func bench(ctx context.Context, client *http.Client, limiter *rate.Limiter, targets []Target, quantity int, threads int, duration time.Duration, stats chan Stat) {
+	var deadline time.Time
+	if duration > 0 {
+		deadline = time.Now().Add(duration)
+	}
+	var counter uint64
+	var sent int64
 	var wg sync.WaitGroup
 	for i := 0; i < threads; i++ {
 		wg.Add(1)
-		go visit(url, stats, quantity, &wg)
+		go visit(ctx, client, limiter, targets, &counter, &sent, stats, quantity, deadline, &wg)
 	}
 	wg.Wait()
 }
 
-func visit(url string, stats chan Stat, tc int, wg *sync.WaitGroup) {
+func visit(ctx context.Context, client *http.Client, limiter *rate.Limiter, targets []Target, counter *uint64, sent *int64, stats chan Stat, tc int, deadline time.Time, wg *sync.WaitGroup) {
 	defer wg.Done()
-	if !strings.HasPrefix(url, "http") {
-        	url = "http://" + url
-	}
+	useDuration := !deadline.IsZero()
 	for {
-		var dnsStart, dnsDone, connDone, gotConn, transferInit, done time.Time
-		req, err := http.NewRequest("GET", url, nil)
+		if ctx.Err() != nil {
+			return
+		}
+		if useDuration && time.Now().After(deadline) {
+			return
+		}
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return
+			}
+		}
+
+		var dnsStart, dnsDone, connDone, tlsStart, tlsDone, gotConn, transferInit, done, reqStart time.Time
+		var errClass ErrClass
+
+		req, err := buildRequest(nextTarget(targets, counter))
 		if err != nil {
 			log.Fatalf("new request failed: %v", err)
 		}
@@ -73,6 +163,9 @@ func visit(url string, stats chan Stat, tc int, wg *sync.WaitGroup) {
 			},
 			DNSDone: func(info httptrace.DNSDoneInfo) {
 				dnsDone = time.Now()
+				if info.Err != nil {
+					errClass = ErrDNS
+				}
 			},
 			ConnectStart: func(x, y string) {
 				if dnsDone.IsZero() {
@@ -80,78 +173,115 @@ func visit(url string, stats chan Stat, tc int, wg *sync.WaitGroup) {
 				}
 			},
 			ConnectDone: func(net, addr string, err error) {
+				connDone = time.Now()
 				if err != nil {
-					log.Fatalf("unable to connect to host %v: %v", addr, err)
+					errClass = ErrConnect
+				}
+			},
+			TLSHandshakeStart: func() { tlsStart = time.Now() },
+			TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+				tlsDone = time.Now()
+				if err != nil {
+					errClass = ErrTLS
 				}
-				connDone = time.Now()
 			},
 			GotConn: func(info httptrace.GotConnInfo) {
 				gotConn = time.Now()
+				if info.Reused {
+					// DNSStart/ConnectStart never fire for a connection handed
+					// back out of the idle pool, so anchor Total at reqStart
+					// instead of leaving dnsStart zero (which would make
+					// Total saturate to the max time.Duration below).
+					dnsStart = reqStart
+					dnsDone = reqStart
+					connDone = reqStart
+				}
 			},
 			GotFirstResponseByte: func() { transferInit = time.Now() },
 		}
-		req = req.WithContext(httptrace.WithClientTrace(context.Background(), trace))
-		tr := &http.Transport{
-			Proxy:                 http.ProxyFromEnvironment,
-			MaxIdleConns:          100,
-			IdleConnTimeout:       30 * time.Second,
-			TLSHandshakeTimeout:   10 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-		}
-		client := &http.Client{
-			Transport: tr,
-		}
+		req = req.WithContext(httptrace.WithClientTrace(ctx, trace))
+		reqStart = time.Now()
 		resp, err := client.Do(req)
 		if err != nil {
-			log.Fatalf("request failed: %v", err)
+			if ctx.Err() != nil {
+				return
+			}
+			if errClass == ErrNone {
+				if os.IsTimeout(err) {
+					errClass = ErrTimeout
+				} else {
+					errClass = ErrConnect
+				}
+			}
+			done = time.Now()
+			stats <- buildErrStat(reqStart, dnsStart, dnsDone, connDone, tlsStart, tlsDone, done, errClass, 0)
+			if !useDuration && atomic.AddInt64(sent, 1) >= int64(tc) {
+				return
+			}
+			continue
 		}
-		fmt.Printf("%s\n", resp.Status)
+
+		_, readErr := io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
 		done = time.Now()
+		if readErr != nil {
+			errClass = ErrRead
+		} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			errClass = ErrNon2xx
+		}
+
 		if transferInit.IsZero() {
 			transferInit = done
 		}
 		if dnsStart.IsZero() {
 			dnsStart = dnsDone
 		}
+		if dnsStart.IsZero() {
+			dnsStart = reqStart
+		}
+		if tlsStart.IsZero() {
+			tlsStart = connDone
+			tlsDone = connDone
+		}
 		stat := Stat{
 			DNSLookup:        dnsDone.Sub(dnsStart),
 			TCPConnection:    connDone.Sub(dnsDone),
-			TLSHandshake:     gotConn.Sub(connDone),
+			TLSHandshake:     tlsDone.Sub(tlsStart),
 			ServerProccesing: transferInit.Sub(gotConn),
 			ContentTransfer:  done.Sub(transferInit),
 			Total:            done.Sub(dnsStart),
+			StatusCode:       resp.StatusCode,
+			Err:              errClass,
 		}
 		stats <- stat
-		if len(stats) >= tc {
-			break
+		if !useDuration && atomic.AddInt64(sent, 1) >= int64(tc) {
+			return
 		}
 	}
 }
 
-func sumarize(stats chan Stat, w io.Writer) {
-	summ := Stat{}
-	size := int64(len(stats))
-	close(stats)
-	for s := range stats {
-		summ.DNSLookup = time.Duration(summ.DNSLookup.Nanoseconds() + s.DNSLookup.Nanoseconds())
-		summ.TCPConnection = time.Duration(summ.TCPConnection.Nanoseconds() + s.TCPConnection.Nanoseconds())
-		summ.TLSHandshake = time.Duration(summ.TLSHandshake.Nanoseconds() + s.TLSHandshake.Nanoseconds())
-		summ.ServerProccesing = time.Duration(summ.ServerProccesing.Nanoseconds() + s.ServerProccesing.Nanoseconds())
-		summ.ContentTransfer = time.Duration(summ.ContentTransfer.Nanoseconds() + s.ContentTransfer.Nanoseconds())
-		summ.Total = time.Duration(summ.Total.Nanoseconds() + s.Total.Nanoseconds())
-	}
-	summ.DNSLookup = time.Duration((summ.DNSLookup.Nanoseconds() / size))
-	summ.TCPConnection = time.Duration((summ.TCPConnection.Nanoseconds() / size))
-	summ.TLSHandshake = time.Duration((summ.TLSHandshake.Nanoseconds() / size))
-	summ.ServerProccesing = time.Duration((summ.ServerProccesing.Nanoseconds() / size))
-	summ.ContentTransfer = time.Duration((summ.ContentTransfer.Nanoseconds() / size))
-	summ.Total = time.Duration((summ.Total.Nanoseconds() / size))
-	sumaryTmpl := `Average request time: {{.Total.Seconds }}s
-DNS Lookup: {{ .DNSLookup.Seconds }}s
-TCP Connections: {{ .TCPConnection.Seconds }}s
-Server Procesing: {{ .ServerProccesing.Seconds }}s
-Server Tranfer: {{ .ContentTransfer.Seconds }}s
-`
-	tmpl, _ := template.New("summary").Parse(sumaryTmpl)
-	tmpl.Execute(w, summ)
+// buildErrStat produces the Stat recorded when a request fails before a
+// response was ever received. Whatever phase timestamps did fire are
+// preserved; unreached phases stay zero. reqStart anchors Total when the
+// failure struck before any trace callback fired (e.g. a reused
+// connection that timed out before GotConn, or ctx cancellation).
+func buildErrStat(reqStart, dnsStart, dnsDone, connDone, tlsStart, tlsDone, done time.Time, errClass ErrClass, statusCode int) Stat {
+	if dnsStart.IsZero() {
+		dnsStart = dnsDone
+	}
+	if dnsStart.IsZero() {
+		dnsStart = reqStart
+	}
+	if tlsStart.IsZero() {
+		tlsStart = connDone
+		tlsDone = connDone
+	}
+	return Stat{
+		DNSLookup:     dnsDone.Sub(dnsStart),
+		TCPConnection: connDone.Sub(dnsDone),
+		TLSHandshake:  tlsDone.Sub(tlsStart),
+		Total:         done.Sub(dnsStart),
+		StatusCode:    statusCode,
+		Err:           errClass,
+	}
 }
@@ -2,72 +2,86 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 )
 
-func TestVisitURL(t *testing.T) {
-	server := httptest.NewServer(nil)
-	stat := []Stat{}
+func TestVisitCollectsStat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := buildClient()
+	if err != nil {
+		t.Fatalf("buildClient returned error: %v", err)
+	}
+	targets := []Target{{URL: server.URL}}
+	stats := make(chan Stat, 1)
+	var counter uint64
+	var sent int64
 	var wg sync.WaitGroup
 	wg.Add(1)
-	visit(server.URL, &stat, &wg)
+	go visit(context.Background(), client, nil, targets, &counter, &sent, stats, 1, time.Time{}, &wg)
 	wg.Wait()
-	if len(stat) == 0 {
-		t.Fatalf("Got Error. Expecting one stat, got zero.")
+	close(stats)
+
+	samples := []Stat{}
+	for s := range stats {
+		samples = append(samples, s)
+	}
+	if len(samples) != 1 {
+		t.Fatalf("expected 1 stat, got %d", len(samples))
+	}
+	if samples[0].StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", samples[0].StatusCode)
+	}
+	if samples[0].Total <= 0 {
+		t.Fatalf("expected a positive Total, got %v", samples[0].Total)
 	}
 }
 
-func TestSumarizeStat(t *testing.T) {
-	expected := `Average request time: 1s
-DNS Lookup: 0.2s
-TCP Connections: 0.2s
-Server Procesing: 0.2s
-Server Tranfer: 0.4s
-`
-	stats := []Stat{{
+func TestTextReporter(t *testing.T) {
+	stat := Stat{
 		DNSLookup:        time.Duration(0.2 * float64(time.Second)),
 		TCPConnection:    time.Duration(0.2 * float64(time.Second)),
 		ServerProccesing: time.Duration(0.2 * float64(time.Second)),
 		ContentTransfer:  time.Duration(0.4 * float64(time.Second)),
 		Total:            time.Duration(1 * time.Second),
-	}}
+	}
 	var buf bytes.Buffer
-	sumarize(stats, &buf)
-	if buf.String() != expected {
-		t.Fatalf("Wrong sumary")
+	if err := (textReporter{}).Report([]Stat{stat}, time.Second, &buf); err != nil {
+		t.Fatalf("Report returned error: %v", err)
 	}
-}
-
-func runMainForTest(t *testing.T, wantedExit int, args ...string) {
-	exit := Main(args...)
 
-	if exit != wantedExit {
-		t.Fatalf("got exit code %d, but wanted %d", exit, wantedExit)
+	out := buf.String()
+	if !strings.Contains(out, "Requests:      1") {
+		t.Fatalf("expected request count in summary, got: %s", out)
 	}
-}
-
-func TestCallWithoutConnectionFlag(t *testing.T) {
-
-	called := false
-	usage = func() { called = true }
-
-	runMainForTest(t, 1, "http://dummydomain.com")
-
-	if !called {
-		t.Error("should call usage without -c flag")
+	if !strings.Contains(out, "Total") {
+		t.Fatalf("expected Total phase in summary, got: %s", out)
+	}
+	if !strings.Contains(out, "p99=") {
+		t.Fatalf("expected p99 percentile in summary, got: %s", out)
 	}
 }
 
-func TestCallWithoutUrl(t *testing.T) {
-	called := false
-	usage = func() { called = true }
-
-	runMainForTest(t, 1, "-c", "10")
+func TestLoadTargetsRejectsEmptyFile(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/empty.txt"
+	if err := os.WriteFile(path, []byte("# just a comment\n"), 0644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+	urlsFile = path
+	defer func() { urlsFile = "" }()
 
-	if !called {
-		t.Error("should call usage wihout url")
+	if _, err := loadTargets(""); err == nil {
+		t.Fatalf("expected an error for a -f file with no URLs")
 	}
 }
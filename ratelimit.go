@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+
+	"golang.org/x/time/rate"
+)
+
+var rps float64
+
+func init() {
+	flag.Float64Var(&rps, "r", 0, "Max requests/sec across all connections combined. Zero means unlimited.")
+}
+
+// buildLimiter returns a rate.Limiter shared by every worker so they draw
+// from one token bucket instead of free-running, or nil when -r was left
+// unset (unlimited).
+func buildLimiter() *rate.Limiter {
+	if rps <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(rps), c)
+}
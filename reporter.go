@@ -0,0 +1,330 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+var (
+	outputFormat string
+	outputFile   string
+)
+
+func init() {
+	flag.StringVar(&outputFormat, "o", "text", "Output format: text, json, csv or prom.")
+	flag.StringVar(&outputFile, "out", "", "Write the report to this file instead of stdout.")
+}
+
+// Reporter renders a completed benchmark run. Implementations differ only
+// in how they serialize the same []Stat + elapsed-time data, so callers
+// (downstream tooling, dashboards, pandas) can pick whichever shape suits
+// them via -o.
+type Reporter interface {
+	Report(samples []Stat, elapsed time.Duration, w io.Writer) error
+}
+
+// newReporter resolves the -o flag value to a Reporter, or an error
+// listing the supported formats.
+func newReporter(format string) (Reporter, error) {
+	switch format {
+	case "", "text":
+		return textReporter{}, nil
+	case "json":
+		return jsonReporter{}, nil
+	case "csv":
+		return csvReporter{}, nil
+	case "prom":
+		return promReporter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q (want text, json, csv or prom)", format)
+	}
+}
+
+// openOutput opens -out for writing, or returns os.Stdout when it was left
+// unset.
+func openOutput(path string) (io.WriteCloser, error) {
+	if path == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening -out %s: %w", path, err)
+	}
+	return f, nil
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// phaseReport pairs a phase name with its computed statistics so the
+// summary template can range over them in a stable order.
+type phaseReport struct {
+	Name  string
+	Stats PhaseStats
+}
+
+// errCount is one row of the error histogram.
+type errCount struct {
+	Class ErrClass
+	Count int
+}
+
+// classReport is the Total-latency percentiles for one status-code class
+// (2xx/3xx/4xx/5xx/error).
+type classReport struct {
+	Class string
+	Count int
+	Stats PhaseStats
+}
+
+// Report is the aggregate view shared by the text and prom reporters:
+// per-phase statistics, the error histogram, latency-by-status-class, and
+// overall throughput.
+type Report struct {
+	Phases      []phaseReport
+	Requests    int
+	Elapsed     time.Duration
+	RequestsSec float64
+	Errors      []errCount
+	ByClass     []classReport
+}
+
+var phaseOrder = []string{"DNS Lookup", "TCP Connection", "TLS Handshake", "Server Processing", "Content Transfer", "Total"}
+
+// statusClass buckets a Stat into "2xx".."5xx" or "error" when it never
+// received a response at all.
+func statusClass(s Stat) string {
+	if s.StatusCode == 0 {
+		return "error"
+	}
+	return fmt.Sprintf("%dxx", s.StatusCode/100)
+}
+
+// buildReport computes per-phase min/max/mean/stddev and percentiles
+// across the whole run, an error histogram, and Total latency split by
+// status-code class. elapsed is the wall-clock duration of the benchmark,
+// used to derive requests/sec.
+func buildReport(samples []Stat, elapsed time.Duration) Report {
+	byPhase := map[string][]time.Duration{
+		"DNS Lookup":        make([]time.Duration, 0, len(samples)),
+		"TCP Connection":    make([]time.Duration, 0, len(samples)),
+		"TLS Handshake":     make([]time.Duration, 0, len(samples)),
+		"Server Processing": make([]time.Duration, 0, len(samples)),
+		"Content Transfer":  make([]time.Duration, 0, len(samples)),
+		"Total":             make([]time.Duration, 0, len(samples)),
+	}
+	errHist := map[ErrClass]int{}
+	byClass := map[string][]time.Duration{}
+	var classOrder []string
+	for _, s := range samples {
+		byPhase["DNS Lookup"] = append(byPhase["DNS Lookup"], s.DNSLookup)
+		byPhase["TCP Connection"] = append(byPhase["TCP Connection"], s.TCPConnection)
+		byPhase["TLS Handshake"] = append(byPhase["TLS Handshake"], s.TLSHandshake)
+		byPhase["Server Processing"] = append(byPhase["Server Processing"], s.ServerProccesing)
+		byPhase["Content Transfer"] = append(byPhase["Content Transfer"], s.ContentTransfer)
+		byPhase["Total"] = append(byPhase["Total"], s.Total)
+
+		if s.Err != ErrNone {
+			errHist[s.Err]++
+		}
+		class := statusClass(s)
+		if _, ok := byClass[class]; !ok {
+			classOrder = append(classOrder, class)
+		}
+		byClass[class] = append(byClass[class], s.Total)
+	}
+
+	report := Report{
+		Requests:    len(samples),
+		Elapsed:     elapsed,
+		RequestsSec: float64(len(samples)) / elapsed.Seconds(),
+	}
+	for _, name := range phaseOrder {
+		report.Phases = append(report.Phases, phaseReport{Name: name, Stats: computePhaseStats(byPhase[name])})
+	}
+	for _, class := range classOrder {
+		totals := byClass[class]
+		report.ByClass = append(report.ByClass, classReport{Class: class, Count: len(totals), Stats: computePhaseStats(totals)})
+	}
+	for class, count := range errHist {
+		report.Errors = append(report.Errors, errCount{Class: class, Count: count})
+	}
+	return report
+}
+
+const summaryTmpl = `Requests:      {{ .Requests }}
+Duration:      {{ .Elapsed }}
+Requests/sec:  {{ printf "%.2f" .RequestsSec }}
+
+{{ range .Phases }}{{ printf "%-18s" .Name }}min={{ .Stats.Min }}	mean={{ .Stats.Mean }}	max={{ .Stats.Max }}	sd={{ .Stats.StdDev }}	p50={{ .Stats.P50 }}	p90={{ .Stats.P90 }}	p95={{ .Stats.P95 }}	p99={{ .Stats.P99 }}
+{{ end }}
+Latency by status class:
+{{ range .ByClass }}{{ printf "%-8s" .Class }}count={{ .Count }}	mean={{ .Stats.Mean }}	p50={{ .Stats.P50 }}	p95={{ .Stats.P95 }}	p99={{ .Stats.P99 }}
+{{ end }}
+{{ if .Errors }}Errors:
+{{ range .Errors }}{{ printf "%-10s" .Class }}{{ .Count }}
+{{ end }}{{ end }}`
+
+// textReporter renders the existing tabular human-readable summary.
+type textReporter struct{}
+
+func (textReporter) Report(samples []Stat, elapsed time.Duration, w io.Writer) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	report := buildReport(samples, elapsed)
+	tmpl, err := template.New("summary").Parse(summaryTmpl)
+	if err != nil {
+		log.Fatalf("invalid summary template: %v", err)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+	if err := tmpl.Execute(tw, report); err != nil {
+		return fmt.Errorf("rendering summary: %w", err)
+	}
+	return tw.Flush()
+}
+
+// jsonRecord is one newline-delimited JSON object: either a completed
+// request (Aggregate == false) or the final aggregate summary.
+type jsonRecord struct {
+	Aggregate   bool     `json:"aggregate"`
+	DNSLookup   *float64 `json:"dns_lookup_s,omitempty"`
+	TCP         *float64 `json:"tcp_connection_s,omitempty"`
+	TLS         *float64 `json:"tls_handshake_s,omitempty"`
+	ServerProc  *float64 `json:"server_processing_s,omitempty"`
+	Transfer    *float64 `json:"content_transfer_s,omitempty"`
+	Total       *float64 `json:"total_s,omitempty"`
+	StatusCode  int      `json:"status_code,omitempty"`
+	Err         ErrClass `json:"error,omitempty"`
+	Requests    int      `json:"requests,omitempty"`
+	Elapsed     *float64 `json:"elapsed_s,omitempty"`
+	RequestsSec float64  `json:"requests_per_sec,omitempty"`
+}
+
+// jsonReporter writes newline-delimited JSON: one object per completed
+// request, followed by a final aggregate object.
+type jsonReporter struct{}
+
+func (jsonReporter) Report(samples []Stat, elapsed time.Duration, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, s := range samples {
+		secs := func(d time.Duration) *float64 { v := d.Seconds(); return &v }
+		rec := jsonRecord{
+			DNSLookup:  secs(s.DNSLookup),
+			TCP:        secs(s.TCPConnection),
+			TLS:        secs(s.TLSHandshake),
+			ServerProc: secs(s.ServerProccesing),
+			Transfer:   secs(s.ContentTransfer),
+			Total:      secs(s.Total),
+			StatusCode: s.StatusCode,
+			Err:        s.Err,
+		}
+		if err := enc.Encode(rec); err != nil {
+			return fmt.Errorf("encoding sample: %w", err)
+		}
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+	elapsedSecs := elapsed.Seconds()
+	agg := jsonRecord{
+		Aggregate:   true,
+		Requests:    len(samples),
+		Elapsed:     &elapsedSecs,
+		RequestsSec: float64(len(samples)) / elapsed.Seconds(),
+	}
+	return enc.Encode(agg)
+}
+
+// csvReporter dumps every raw sample as a CSV row.
+type csvReporter struct{}
+
+func (csvReporter) Report(samples []Stat, elapsed time.Duration, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"dns_lookup_s", "tcp_connection_s", "tls_handshake_s", "server_processing_s", "content_transfer_s", "total_s", "status_code", "error"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, s := range samples {
+		row := []string{
+			strconv.FormatFloat(s.DNSLookup.Seconds(), 'f', -1, 64),
+			strconv.FormatFloat(s.TCPConnection.Seconds(), 'f', -1, 64),
+			strconv.FormatFloat(s.TLSHandshake.Seconds(), 'f', -1, 64),
+			strconv.FormatFloat(s.ServerProccesing.Seconds(), 'f', -1, 64),
+			strconv.FormatFloat(s.ContentTransfer.Seconds(), 'f', -1, 64),
+			strconv.FormatFloat(s.Total.Seconds(), 'f', -1, 64),
+			strconv.Itoa(s.StatusCode),
+			string(s.Err),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// promReporter dumps a Prometheus text-exposition summary of each phase's
+// histogram (count/sum plus quantile buckets), suitable for `node
+// exporter`-style textfile collection.
+type promReporter struct{}
+
+func (promReporter) Report(samples []Stat, elapsed time.Duration, w io.Writer) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	report := buildReport(samples, elapsed)
+
+	fmt.Fprintf(w, "# HELP benchstats_requests_total Total requests issued.\n")
+	fmt.Fprintf(w, "# TYPE benchstats_requests_total counter\n")
+	fmt.Fprintf(w, "benchstats_requests_total %d\n", report.Requests)
+	fmt.Fprintf(w, "# HELP benchstats_requests_per_second Observed throughput.\n")
+	fmt.Fprintf(w, "# TYPE benchstats_requests_per_second gauge\n")
+	fmt.Fprintf(w, "benchstats_requests_per_second %f\n", report.RequestsSec)
+
+	fmt.Fprintf(w, "# HELP benchstats_phase_seconds Per-phase latency summary.\n")
+	fmt.Fprintf(w, "# TYPE benchstats_phase_seconds summary\n")
+	for _, p := range report.Phases {
+		phase := promLabel(p.Name)
+		fmt.Fprintf(w, "benchstats_phase_seconds{phase=%q,quantile=\"0.5\"} %f\n", phase, p.Stats.P50.Seconds())
+		fmt.Fprintf(w, "benchstats_phase_seconds{phase=%q,quantile=\"0.9\"} %f\n", phase, p.Stats.P90.Seconds())
+		fmt.Fprintf(w, "benchstats_phase_seconds{phase=%q,quantile=\"0.95\"} %f\n", phase, p.Stats.P95.Seconds())
+		fmt.Fprintf(w, "benchstats_phase_seconds{phase=%q,quantile=\"0.99\"} %f\n", phase, p.Stats.P99.Seconds())
+	}
+
+	if len(report.Errors) > 0 {
+		fmt.Fprintf(w, "# HELP benchstats_errors_total Requests by error class.\n")
+		fmt.Fprintf(w, "# TYPE benchstats_errors_total counter\n")
+		for _, e := range report.Errors {
+			fmt.Fprintf(w, "benchstats_errors_total{class=%q} %d\n", string(e.Class), e.Count)
+		}
+	}
+	return nil
+}
+
+// promLabel lower-snake-cases a phase name for use as a Prometheus label
+// value, e.g. "DNS Lookup" -> "dns_lookup".
+func promLabel(name string) string {
+	out := make([]rune, 0, len(name))
+	for _, r := range name {
+		if r == ' ' {
+			out = append(out, '_')
+			continue
+		}
+		if r >= 'A' && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Target is one endpoint to benchmark: an HTTP method, URL, and optional
+// request body, either loaded from -f or derived from the single
+// positional URL argument.
+type Target struct {
+	Method string
+	URL    string
+	Body   string
+}
+
+// headerList collects repeated -H "Key: Value" flags.
+type headerList []string
+
+func (h *headerList) String() string { return strings.Join(*h, ", ") }
+
+func (h *headerList) Set(v string) error {
+	*h = append(*h, v)
+	return nil
+}
+
+var (
+	urlsFile   string
+	method     string
+	headers    headerList
+	bodyFlag   string
+	basicAuth  string
+	randomPick bool
+
+	// resolvedBodyFlag is bodyFlag with any "@file" already read from
+	// disk, computed once by resolveBodyFlag instead of on every
+	// request in visit's hot loop.
+	resolvedBodyFlag string
+)
+
+func init() {
+	flag.StringVar(&urlsFile, "f", "", `File with one URL per line (optionally "METHOD URL [JSON_BODY]"), benchmarked instead of a single positional URL.`)
+	flag.StringVar(&method, "X", "", "HTTP method to use, overriding any method given via -f. Defaults to GET.")
+	flag.Var(&headers, "H", `Extra request header "Key: Value". Repeatable.`)
+	flag.StringVar(&bodyFlag, "b", "", "Request body, overriding any body given via -f. Use @file to read the body from disk.")
+	flag.StringVar(&basicAuth, "u", "", "Basic auth credentials as user:pass.")
+	flag.BoolVar(&randomPick, "random", false, "Pick targets randomly instead of round-robin when -f lists more than one.")
+}
+
+// loadTargets builds the list of targets to benchmark: either every line
+// of -f, or the single positional URL.
+func loadTargets(positionalURL string) ([]Target, error) {
+	if urlsFile == "" {
+		return []Target{{URL: positionalURL}}, nil
+	}
+
+	f, err := os.Open(urlsFile)
+	if err != nil {
+		return nil, fmt.Errorf("opening -f %s: %w", urlsFile, err)
+	}
+	defer f.Close()
+
+	var targets []Target
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, parseTargetLine(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading -f %s: %w", urlsFile, err)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("-f %s contained no URLs", urlsFile)
+	}
+	return targets, nil
+}
+
+// parseTargetLine parses one line of a -f file: "URL", "METHOD URL", or
+// "METHOD URL JSON_BODY" (the body is the remainder of the line).
+func parseTargetLine(line string) Target {
+	fields := strings.SplitN(line, " ", 3)
+	if len(fields) == 1 {
+		return Target{URL: fields[0]}
+	}
+	return Target{
+		Method: fields[0],
+		URL:    fields[1],
+		Body:   strings.TrimSpace(strings.Join(fields[2:], " ")),
+	}
+}
+
+// nextTarget returns the next target to request: sequential round-robin
+// by default, or uniformly random when -random is set.
+func nextTarget(targets []Target, counter *uint64) Target {
+	if randomPick {
+		return targets[rand.Intn(len(targets))]
+	}
+	i := atomic.AddUint64(counter, 1) - 1
+	return targets[i%uint64(len(targets))]
+}
+
+// resolveBodyFlag reads -b's "@file" from disk once at startup, so
+// buildRequest's per-request hot loop never touches the filesystem.
+func resolveBodyFlag() error {
+	if !strings.HasPrefix(bodyFlag, "@") {
+		resolvedBodyFlag = bodyFlag
+		return nil
+	}
+	data, err := os.ReadFile(bodyFlag[1:])
+	if err != nil {
+		return fmt.Errorf("reading body file %s: %w", bodyFlag[1:], err)
+	}
+	resolvedBodyFlag = string(data)
+	return nil
+}
+
+// resolveBody returns the body to send: -b (already resolved by
+// resolveBodyFlag) wins over the target's own body.
+func resolveBody(target Target) string {
+	if bodyFlag != "" {
+		return resolvedBodyFlag
+	}
+	return target.Body
+}
+
+// buildRequest assembles the *http.Request for one target, applying the
+// -X, -H, -b and -u flags on top of whatever the target itself specifies.
+func buildRequest(target Target) (*http.Request, error) {
+	url := target.URL
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = "http://" + url
+	}
+
+	m := target.Method
+	if method != "" {
+		m = method
+	}
+	if m == "" {
+		m = http.MethodGet
+	}
+
+	body := resolveBody(target)
+	var bodyReader io.Reader
+	if body != "" {
+		bodyReader = strings.NewReader(body)
+	}
+
+	req, err := http.NewRequest(m, url, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range headers {
+		k, v, ok := strings.Cut(h, ":")
+		if !ok {
+			continue
+		}
+		req.Header.Set(strings.TrimSpace(k), strings.TrimSpace(v))
+	}
+	if body != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if basicAuth != "" {
+		user, pass, _ := strings.Cut(basicAuth, ":")
+		req.SetBasicAuth(user, pass)
+	}
+	return req, nil
+}
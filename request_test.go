@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestParseTargetLine(t *testing.T) {
+	tgt := parseTargetLine(`POST http://example.com/y {"a": 1}`)
+	if tgt.Method != "POST" || tgt.URL != "http://example.com/y" || tgt.Body != `{"a": 1}` {
+		t.Fatalf("got %+v", tgt)
+	}
+
+	urlOnly := parseTargetLine("http://example.com/z")
+	if urlOnly.Method != "" || urlOnly.URL != "http://example.com/z" || urlOnly.Body != "" {
+		t.Fatalf("got %+v", urlOnly)
+	}
+}
+
+func TestBuildRequestAppliesFlags(t *testing.T) {
+	headers = headerList{"X-Test: 1"}
+	basicAuth = "bob:secret"
+	defer func() {
+		headers = nil
+		basicAuth = ""
+	}()
+
+	req, err := buildRequest(Target{Method: "POST", URL: "example.com", Body: `{"a":1}`})
+	if err != nil {
+		t.Fatalf("buildRequest returned error: %v", err)
+	}
+	if req.Method != "POST" {
+		t.Fatalf("expected POST, got %s", req.Method)
+	}
+	if req.URL.Scheme != "http" {
+		t.Fatalf("expected default http scheme, got %s", req.URL.Scheme)
+	}
+	if req.Header.Get("X-Test") != "1" {
+		t.Fatalf("expected -H header to be applied")
+	}
+	if user, pass, ok := req.BasicAuth(); !ok || user != "bob" || pass != "secret" {
+		t.Fatalf("expected basic auth bob:secret, got %s:%s (ok=%v)", user, pass, ok)
+	}
+	if req.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("expected JSON body to default Content-Type")
+	}
+}
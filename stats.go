@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// PhaseStats holds the aggregate timing statistics for a single phase
+// (DNS lookup, TCP connection, etc.) across every sample collected during
+// a benchmark run.
+type PhaseStats struct {
+	Min    time.Duration
+	Max    time.Duration
+	Mean   time.Duration
+	StdDev time.Duration
+	P50    time.Duration
+	P90    time.Duration
+	P95    time.Duration
+	P99    time.Duration
+}
+
+// computePhaseStats sorts samples and derives min/max/mean/stddev plus the
+// p50/p90/p95/p99 percentiles. It returns the zero value when samples is
+// empty.
+func computePhaseStats(samples []time.Duration) PhaseStats {
+	if len(samples) == 0 {
+		return PhaseStats{}
+	}
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum time.Duration
+	for _, s := range sorted {
+		sum += s
+	}
+	mean := sum / time.Duration(len(sorted))
+
+	var variance float64
+	for _, s := range sorted {
+		d := float64(s - mean)
+		variance += d * d
+	}
+	variance /= float64(len(sorted))
+
+	return PhaseStats{
+		Min:    sorted[0],
+		Max:    sorted[len(sorted)-1],
+		Mean:   mean,
+		StdDev: time.Duration(math.Sqrt(variance)),
+		P50:    percentile(sorted, 0.50),
+		P90:    percentile(sorted, 0.90),
+		P95:    percentile(sorted, 0.95),
+		P99:    percentile(sorted, 0.99),
+	}
+}
+
+// percentile returns the p-th percentile (0 <= p <= 1) of an already
+// sorted slice using nearest-rank interpolation.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(p * float64(len(sorted)-1))
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"os"
+)
+
+var (
+	insecureSkipVerify bool
+	clientCertFile     string
+	clientKeyFile      string
+	caCertFile         string
+	tlsServerName      string
+)
+
+func init() {
+	flag.BoolVar(&insecureSkipVerify, "k", false, "Skip TLS certificate verification.")
+	flag.BoolVar(&insecureSkipVerify, "insecure", false, "Skip TLS certificate verification.")
+	flag.StringVar(&clientCertFile, "cert", "", "Client TLS certificate file (PEM).")
+	flag.StringVar(&clientKeyFile, "key", "", "Client TLS private key file (PEM), paired with -cert.")
+	flag.StringVar(&caCertFile, "cacert", "", "CA bundle file (PEM) used to verify the server certificate.")
+	flag.StringVar(&tlsServerName, "sni", "", "Server name to use for SNI and certificate verification, overriding the host in the URL.")
+}
+
+// buildTLSConfig assembles a *tls.Config from the -k, -cert/-key, -cacert
+// and -sni flags. It returns nil when none of them were set, so callers
+// can fall back to Go's zero-value (default) TLS behavior.
+func buildTLSConfig() (*tls.Config, error) {
+	if !insecureSkipVerify && clientCertFile == "" && caCertFile == "" && tlsServerName == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		InsecureSkipVerify: insecureSkipVerify,
+		ServerName:         tlsServerName,
+	}
+
+	if clientCertFile != "" || clientKeyFile != "" {
+		if clientCertFile == "" || clientKeyFile == "" {
+			return nil, fmt.Errorf("-cert and -key must be given together")
+		}
+		cert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if caCertFile != "" {
+		pem, err := os.ReadFile(caCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caCertFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"net/http"
+	"time"
+)
+
+var (
+	maxIdleConnsPerHost int
+	disableKeepAlives   bool
+	http2Enabled        bool
+)
+
+func init() {
+	flag.IntVar(&maxIdleConnsPerHost, "max-idle-per-host", 2, "Max idle (keep-alive) connections to keep per host.")
+	flag.BoolVar(&disableKeepAlives, "disable-keepalives", false, "Disable HTTP keep-alives, opening a new connection per request.")
+	flag.BoolVar(&http2Enabled, "http2", false, "Allow negotiating HTTP/2 over TLS. Off by default to keep phase timings comparable across requests.")
+}
+
+// buildClient assembles the single *http.Client shared by every worker
+// goroutine for the lifetime of a benchmark run, so connections and idle
+// conns are actually reused instead of being rebuilt per request.
+func buildClient() (*http.Client, error) {
+	tlsConfig, err := buildTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	tr := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		DisableKeepAlives:     disableKeepAlives,
+		IdleConnTimeout:       30 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       tlsConfig,
+	}
+	if !http2Enabled {
+		// An empty, non-nil TLSNextProto map is net/http's documented way
+		// to opt out of the automatic HTTP/2 upgrade over TLS.
+		tr.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	return &http.Client{
+		Transport: tr,
+		Timeout:   timeout,
+	}, nil
+}